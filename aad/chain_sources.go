@@ -0,0 +1,217 @@
+package aad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go/auth"
+	"github.com/pkg/errors"
+)
+
+const (
+	envFederatedTokenFile = "AZURE_FEDERATED_TOKEN_FILE"
+	envClientID           = "AZURE_CLIENT_ID"
+	envTenantID           = "AZURE_TENANT_ID"
+	envAuthorityHost      = "AZURE_AUTHORITY_HOST"
+
+	defaultAuthorityHost = "https://login.microsoftonline.com/"
+	imdsTokenEndpoint    = "http://169.254.169.254/metadata/identity/oauth2/token"
+	imdsAPIVersion       = "2019-08-01"
+
+	httpRequestTimeout = 10 * time.Second
+)
+
+// workloadIdentityProvider exchanges the projected service account token AKS mounts for pods
+// using workload identity federation for an AAD access token, via the client-assertion JWT
+// bearer flow.
+type workloadIdentityProvider struct {
+	tenantID      string
+	clientID      string
+	tokenFilePath string
+	authorityHost string
+	client        *http.Client
+}
+
+func newWorkloadIdentityProvider() (*workloadIdentityProvider, error) {
+	tokenFilePath := os.Getenv(envFederatedTokenFile)
+	clientID := os.Getenv(envClientID)
+	tenantID := os.Getenv(envTenantID)
+
+	if tokenFilePath == "" || clientID == "" || tenantID == "" {
+		return nil, errors.Errorf("%s, %s and %s must all be set", envFederatedTokenFile, envClientID, envTenantID)
+	}
+
+	authorityHost := os.Getenv(envAuthorityHost)
+	if authorityHost == "" {
+		authorityHost = defaultAuthorityHost
+	}
+
+	return &workloadIdentityProvider{
+		tenantID:      tenantID,
+		clientID:      clientID,
+		tokenFilePath: tokenFilePath,
+		authorityHost: authorityHost,
+		client:        &http.Client{Timeout: httpRequestTimeout},
+	}, nil
+}
+
+func (w *workloadIdentityProvider) GetToken(uri string) (*auth.Token, error) {
+	assertion, err := ioutil.ReadFile(w.tokenFilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "aad: failed to read federated token file")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", w.clientID)
+	form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	form.Set("scope", scopeFor(uri))
+
+	endpoint := strings.TrimRight(w.authorityHost, "/") + "/" + w.tenantID + "/oauth2/v2.0/token"
+	return requestAADToken(w.client, endpoint, form)
+}
+
+// managedIdentityProvider fetches a token for the current host's system-assigned managed
+// identity from the Azure Instance Metadata Service, available on Azure VMs and most PaaS
+// compute.
+type managedIdentityProvider struct {
+	resource string
+	client   *http.Client
+}
+
+func newManagedIdentityProvider(resource string) *managedIdentityProvider {
+	return &managedIdentityProvider{resource: resource, client: &http.Client{Timeout: httpRequestTimeout}}
+}
+
+// GetToken ignores uri: a managedIdentityProvider is constructed with the fixed resource it
+// should always request a token for, rather than deriving one per call like the other providers
+// in the chain.
+func (m *managedIdentityProvider) GetToken(_ string) (*auth.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsTokenEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	q := req.URL.Query()
+	q.Set("api-version", imdsAPIVersion)
+	q.Set("resource", resourceFor(m.resource))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "aad: failed to reach instance metadata service")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("aad: instance metadata service returned status %d", resp.StatusCode)
+	}
+
+	return decodeIMDSToken(resp.Body)
+}
+
+// azureCLIProvider delegates to the `az` CLI's cached login, for local development machines.
+type azureCLIProvider struct{}
+
+func newAzureCLIProvider() *azureCLIProvider {
+	return &azureCLIProvider{}
+}
+
+func (a *azureCLIProvider) GetToken(uri string) (*auth.Token, error) {
+	cmd := exec.Command("az", "account", "get-access-token", "--resource", resourceFor(uri), "--output", "json")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "aad: az CLI token acquisition failed; is the Azure CLI installed and logged in?")
+	}
+
+	var parsed struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresOn   string `json:"expiresOn"`
+		TokenType   string `json:"tokenType"`
+	}
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		return nil, errors.Wrap(err, "aad: failed to parse az CLI output")
+	}
+
+	return &auth.Token{
+		TokenType: parsed.TokenType,
+		Token:     parsed.AccessToken,
+		Expiry:    parsed.ExpiresOn,
+	}, nil
+}
+
+func resourceFor(uri string) string {
+	return strings.TrimSuffix(uri, "/") + "/"
+}
+
+func scopeFor(uri string) string {
+	return resourceFor(uri) + ".default"
+}
+
+func requestAADToken(client *http.Client, endpoint string, form url.Values) (*auth.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBufferString(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "aad: failed to reach AAD token endpoint")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.Errorf("aad: token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var parsed struct {
+		TokenType   string `json:"token_type"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, errors.Wrap(err, "aad: failed to parse token response")
+	}
+
+	return &auth.Token{
+		TokenType: parsed.TokenType,
+		Token:     parsed.AccessToken,
+		Expiry:    fmt.Sprintf("%d", time.Now().Add(time.Duration(parsed.ExpiresIn)*time.Second).Unix()),
+	}, nil
+}
+
+func decodeIMDSToken(body io.Reader) (*auth.Token, error) {
+	var parsed struct {
+		TokenType   string `json:"token_type"`
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+	}
+	if err := json.NewDecoder(body).Decode(&parsed); err != nil {
+		return nil, errors.Wrap(err, "aad: failed to parse instance metadata service response")
+	}
+
+	return &auth.Token{
+		TokenType: parsed.TokenType,
+		Token:     parsed.AccessToken,
+		Expiry:    parsed.ExpiresOn,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package eventhub
+
+import (
+	"context"
+)
+
+const (
+	// batchEnvelopeOverhead is reserved up front for the AMQP transfer frame and batch-message
+	// envelope that wraps every event in the batch.
+	batchEnvelopeOverhead = 1024
+
+	// perEventFrameOverhead is charged against each event to account for the AMQP framing and
+	// per-message section headers the broker requires in addition to the event's own payload.
+	perEventFrameOverhead = 64
+)
+
+type (
+	// EventBatchBuilder incrementally assembles an EventBatch while tracking how close it is to
+	// the sender link's negotiated max-message-size, so callers can flush and start a new batch
+	// instead of discovering the overflow only when Hub.SendBatch returns a MessageSizeExceeded
+	// error.
+	EventBatchBuilder struct {
+		maxSizeInBytes uint64
+		partitionKey   *string
+		events         []*Event
+		sizeInBytes    uint64
+	}
+
+	// BatchBuilderOption configures an EventBatchBuilder at construction time.
+	BatchBuilderOption func(b *EventBatchBuilder) error
+)
+
+// NewEventBatchBuilder returns a builder that packs events into a single EventBatch without
+// exceeding the max message size negotiated for the underlying sender link.
+func (h *Hub) NewEventBatchBuilder(ctx context.Context, opts ...BatchBuilderOption) (*EventBatchBuilder, error) {
+	sender, err := h.getSender(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &EventBatchBuilder{
+		maxSizeInBytes: sender.MaxMessageSize(),
+		sizeInBytes:    batchEnvelopeOverhead,
+	}
+
+	for _, opt := range opts {
+		if err := opt(b); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// BatchBuilderWithPartitionKey routes every event added to the builder to the same partition,
+// overriding each event's own PartitionKey.
+func BatchBuilderWithPartitionKey(key string) BatchBuilderOption {
+	return func(b *EventBatchBuilder) error {
+		b.partitionKey = &key
+		return nil
+	}
+}
+
+// TryAdd appends evt to the batch if doing so would not exceed the negotiated max message size.
+// It returns false, without error, when evt was rejected purely because the size budget is
+// exhausted - the caller should Build and send what has been accumulated so far, start a new
+// builder, and retry evt there.
+func (b *EventBatchBuilder) TryAdd(evt *Event) (bool, error) {
+	partitionKey := evt.PartitionKey
+	if b.partitionKey != nil {
+		partitionKey = b.partitionKey
+	}
+
+	size := estimatedEventSize(evt, partitionKey)
+	if b.maxSizeInBytes > 0 && b.sizeInBytes+size > b.maxSizeInBytes {
+		return false, nil
+	}
+
+	evt.PartitionKey = partitionKey
+	b.events = append(b.events, evt)
+	b.sizeInBytes += size
+	return true, nil
+}
+
+// NumEvents returns the number of events currently in the batch.
+func (b *EventBatchBuilder) NumEvents() int {
+	return len(b.events)
+}
+
+// SizeInBytes returns the builder's current estimate of the batch's on-the-wire size, including
+// framing and envelope overhead.
+func (b *EventBatchBuilder) SizeInBytes() uint64 {
+	return b.sizeInBytes
+}
+
+// Build returns the accumulated events as an EventBatch ready to pass to Hub.SendBatch.
+func (b *EventBatchBuilder) Build() *EventBatch {
+	return &EventBatch{Events: b.events}
+}
+
+// estimatedEventSize approximates the on-the-wire size of evt, charging its data, its
+// identifying fields, partitionKey (which may not yet be assigned to evt.PartitionKey), and its
+// application properties against the batch's size budget, plus a fixed per-event framing
+// overhead.
+func estimatedEventSize(evt *Event, partitionKey *string) uint64 {
+	size := uint64(len(evt.Data)) + perEventFrameOverhead
+
+	if evt.ID != "" {
+		size += uint64(len(evt.ID))
+	}
+	if partitionKey != nil {
+		size += uint64(len(*partitionKey))
+	}
+
+	for key, value := range evt.Properties {
+		size += uint64(len(key))
+		if s, ok := value.(string); ok {
+			size += uint64(len(s))
+		} else {
+			// conservative estimate for non-string property values
+			size += 8
+		}
+	}
+
+	return size
+}
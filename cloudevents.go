@@ -0,0 +1,210 @@
+package eventhub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	amqp "github.com/Azure/go-amqp"
+	cloudevents "github.com/cloudevents/sdk-go/v2/event"
+	"github.com/pkg/errors"
+)
+
+// CEMode selects how a CloudEvents v1.0 event is encoded onto (or decoded from) an Event Hubs
+// message: Binary maps attributes to AMQP application properties and leaves the body as raw
+// data, while Structured serializes the whole event as a single JSON document in the body.
+type CEMode int
+
+const (
+	// Binary maps CloudEvents attributes to AMQP application properties prefixed with
+	// cloudEventPropertyPrefix, and places the event data directly in the message body.
+	Binary CEMode = iota
+
+	// Structured serializes the entire CloudEvent, attributes and data together, as a single
+	// application/cloudevents+json document in the message body.
+	Structured
+)
+
+const (
+	cloudEventPropertyPrefix = "cloudEvents:"
+	structuredContentType    = "application/cloudevents+json"
+
+	ceAttrID              = "id"
+	ceAttrSource          = "source"
+	ceAttrType            = "type"
+	ceAttrSpecVersion     = "specversion"
+	ceAttrSubject         = "subject"
+	ceAttrTime            = "time"
+	ceAttrDataContentType = "datacontenttype"
+	ceAttrDataSchema      = "dataschema"
+)
+
+// SendWithCloudEvent encodes ce onto the outgoing AMQP message according to mode, replacing
+// whatever body and properties the Event itself carried. Use alongside Hub.Send / Hub.SendBatch
+// to interoperate with CloudEvents producers and consumers such as Event Grid, Knative, or Dapr.
+func SendWithCloudEvent(ce *cloudevents.Event, mode CEMode) SendOption {
+	return func(msg *amqp.Message) error {
+		if mode == Structured {
+			return applyStructuredCloudEvent(msg, ce)
+		}
+		return applyBinaryCloudEvent(msg, ce)
+	}
+}
+
+func applyBinaryCloudEvent(msg *amqp.Message, ce *cloudevents.Event) error {
+	if msg.ApplicationProperties == nil {
+		msg.ApplicationProperties = make(map[string]interface{})
+	}
+
+	setAttr := func(key, value string) {
+		if value != "" {
+			msg.ApplicationProperties[cloudEventPropertyPrefix+key] = value
+		}
+	}
+
+	setAttr(ceAttrID, ce.ID())
+	setAttr(ceAttrSource, ce.Source())
+	setAttr(ceAttrType, ce.Type())
+	setAttr(ceAttrSpecVersion, ce.SpecVersion())
+	setAttr(ceAttrSubject, ce.Subject())
+	setAttr(ceAttrDataContentType, ce.DataContentType())
+	setAttr(ceAttrDataSchema, ce.DataSchema())
+	if !ce.Time().IsZero() {
+		setAttr(ceAttrTime, ce.Time().Format(time.RFC3339Nano))
+	}
+	for key, value := range ce.Extensions() {
+		msg.ApplicationProperties[cloudEventPropertyPrefix+key] = value
+	}
+
+	if msg.Properties == nil {
+		msg.Properties = &amqp.MessageProperties{}
+	}
+	msg.Properties.ContentType = ce.DataContentType()
+	msg.Data = [][]byte{ce.Data()}
+
+	return nil
+}
+
+func applyStructuredCloudEvent(msg *amqp.Message, ce *cloudevents.Event) error {
+	body, err := ce.MarshalJSON()
+	if err != nil {
+		return errors.Wrap(err, "cloudevents: failed to marshal structured event")
+	}
+
+	if msg.Properties == nil {
+		msg.Properties = &amqp.MessageProperties{}
+	}
+	msg.Properties.ContentType = structuredContentType
+	msg.Data = [][]byte{body}
+
+	return nil
+}
+
+// cloudEventReceivers marks a receiver that is still being constructed as wanting CloudEvents
+// decoding, so that Hub.Receive can wrap its Handler once the receiver is ready. Entries are
+// consumed (and removed) as soon as Hub.Receive reads them.
+var cloudEventReceivers sync.Map
+
+type cloudEventContextKey struct{}
+
+// ReceiveWithCloudEventsDecoding configures a Hub.Receive listener to reconstruct a
+// cloudevents.Event from each incoming message before invoking the Handler. The mode - Binary or
+// Structured - is detected per message from its content-type and application properties, so a
+// single listener can interoperate with producers (Event Grid, Knative, Dapr, ...) that may not
+// agree on which mode they use. The decoded event is retrieved inside the Handler with
+// CloudEventFromContext.
+func ReceiveWithCloudEventsDecoding() ReceiveOption {
+	return func(r *receiver) error {
+		cloudEventReceivers.Store(r, struct{}{})
+		return nil
+	}
+}
+
+// CloudEventFromContext returns the cloudevents.Event decoded for the message currently being
+// handled, when the listener was configured with ReceiveWithCloudEventsDecoding.
+func CloudEventFromContext(ctx context.Context) (*cloudevents.Event, bool) {
+	ce, ok := ctx.Value(cloudEventContextKey{}).(*cloudevents.Event)
+	return ce, ok
+}
+
+func decodeCloudEventsHandler(handler Handler) Handler {
+	return func(ctx context.Context, event *Event) error {
+		ce, err := decodeCloudEvent(event)
+		if err != nil {
+			return errors.Wrap(err, "cloudevents: failed to decode message")
+		}
+		return handler(context.WithValue(ctx, cloudEventContextKey{}, ce), event)
+	}
+}
+
+// decodeCloudEvent detects, from the message's content type and application properties, whether
+// it was encoded in Structured or Binary mode, and decodes it accordingly. Structured mode is
+// recognized by the AMQP properties-section content type - applyStructuredCloudEvent sets
+// msg.Properties.ContentType, which surfaces on the received Event as event.ContentType, not as
+// an application property.
+func decodeCloudEvent(event *Event) (*cloudevents.Event, error) {
+	if event.ContentType == structuredContentType {
+		return decodeStructuredCloudEvent(event)
+	}
+	if _, ok := event.Properties[cloudEventPropertyPrefix+ceAttrSpecVersion]; ok {
+		return decodeBinaryCloudEvent(event)
+	}
+	return nil, errors.New("cloudevents: message carries neither a structured content-type nor binary CloudEvents attributes")
+}
+
+func decodeStructuredCloudEvent(event *Event) (*cloudevents.Event, error) {
+	ce := cloudevents.New()
+	if err := json.Unmarshal(event.Data, &ce); err != nil {
+		return nil, err
+	}
+	return &ce, nil
+}
+
+func decodeBinaryCloudEvent(event *Event) (*cloudevents.Event, error) {
+	getAttr := func(key string) string {
+		if v, ok := event.Properties[cloudEventPropertyPrefix+key]; ok {
+			if s, ok := v.(string); ok {
+				return s
+			}
+		}
+		return ""
+	}
+
+	ce := cloudevents.New()
+	ce.SetID(getAttr(ceAttrID))
+	ce.SetSource(getAttr(ceAttrSource))
+	ce.SetType(getAttr(ceAttrType))
+	ce.SetSubject(getAttr(ceAttrSubject))
+	ce.SetDataSchema(getAttr(ceAttrDataSchema))
+
+	if contentType := getAttr(ceAttrDataContentType); contentType != "" {
+		ce.SetDataContentType(contentType)
+	}
+
+	if t := getAttr(ceAttrTime); t != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse time attribute")
+		}
+		ce.SetTime(parsed)
+	}
+
+	for key, value := range event.Properties {
+		if len(key) <= len(cloudEventPropertyPrefix) || key[:len(cloudEventPropertyPrefix)] != cloudEventPropertyPrefix {
+			continue
+		}
+		switch name := key[len(cloudEventPropertyPrefix):]; name {
+		case ceAttrID, ceAttrSource, ceAttrType, ceAttrSpecVersion, ceAttrSubject, ceAttrTime, ceAttrDataContentType, ceAttrDataSchema:
+			continue
+		default:
+			ce.SetExtension(name, value)
+		}
+	}
+
+	if err := ce.SetData(ce.DataContentType(), event.Data); err != nil {
+		return nil, errors.Wrap(err, "failed to set data")
+	}
+
+	return &ce, nil
+}
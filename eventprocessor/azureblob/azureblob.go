@@ -0,0 +1,181 @@
+// Package azureblob provides a reference Azure Blob Storage implementation of the interfaces
+// eventprocessor.Processor needs to coordinate checkpointing and partition ownership across a
+// fleet of hosts, in the same spirit as the blob-lease based EventProcessorHost used by the
+// older Event Hubs SDKs.
+//
+// One blob is used per (namespace, hub, consumerGroup, partitionID) pair, holding the JSON
+// encoded checkpoint as its contents. Ownership of that same blob is established by acquiring a
+// finite Azure Blob lease that the owner renews on each claim: whichever host holds the lease is
+// the owner, and a lease that nobody is renewing expires on its own, which is exactly the "expired lease" state
+// Processor's balancing logic looks for.
+package azureblob
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-event-hubs-go/eventprocessor"
+	"github.com/Azure/azure-event-hubs-go/persist"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/pkg/errors"
+)
+
+const (
+	blobLeaseDuration = 30 * time.Second
+)
+
+type (
+	// BlobStore implements both persist.CheckpointPersister and eventprocessor.OwnershipStore on
+	// top of an Azure Blob Storage container shared by every host in the consumer group.
+	BlobStore struct {
+		containerURL azblob.ContainerURL
+	}
+)
+
+// NewBlobStore creates a BlobStore backed by the given container. The container must already
+// exist; callers that want one created on demand can use azblob.ContainerURL.Create before
+// passing it in.
+func NewBlobStore(containerURL azblob.ContainerURL) *BlobStore {
+	return &BlobStore{containerURL: containerURL}
+}
+
+func blobName(namespace, hubName, consumerGroup, partitionID string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", namespace, hubName, consumerGroup, partitionID)
+}
+
+// Write persists the checkpoint for the given partition by uploading it as the blob's contents,
+// creating the blob on first use.
+func (s *BlobStore) Write(namespace, hubName, consumerGroup, partitionID string, checkpoint persist.Checkpoint) error {
+	ctx := context.Background()
+	blobURL := s.containerURL.NewBlockBlobURL(blobName(namespace, hubName, consumerGroup, partitionID))
+
+	body, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	// Put Blob replaces the blob's metadata wholesale, so the ownership identity and lease expiry
+	// ClaimOwnership stamped onto this same blob must be read back and carried forward here -
+	// otherwise the very first checkpoint written after a claim would wipe them.
+	// A blob with an active lease only accepts Put Blob under that lease's ID, which is derived
+	// from the owner recorded in the metadata.
+	metadata := azblob.Metadata{}
+	conditions := azblob.BlobAccessConditions{}
+	if props, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{}); err == nil {
+		metadata = props.NewMetadata()
+		if props.LeaseState() == azblob.LeaseStateLeased {
+			conditions.LeaseAccessConditions.LeaseID = leaseIDFor(metadata[ownerMetadataKey])
+		}
+	}
+
+	_, err = blobURL.Upload(ctx, bytesReader(body), azblob.BlobHTTPHeaders{ContentType: "application/json"}, metadata, conditions)
+	return err
+}
+
+// Read returns the last checkpoint written for the given partition, or a start-of-stream
+// checkpoint if the partition has never been checkpointed.
+func (s *BlobStore) Read(namespace, hubName, consumerGroup, partitionID string) (persist.Checkpoint, error) {
+	ctx := context.Background()
+	blobURL := s.containerURL.NewBlockBlobURL(blobName(namespace, hubName, consumerGroup, partitionID))
+
+	resp, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		if isNotFound(err) {
+			return persist.NewCheckpointFromStartOfStream(), nil
+		}
+		return persist.Checkpoint{}, err
+	}
+	defer resp.Response().Body.Close()
+
+	var checkpoint persist.Checkpoint
+	if err := json.NewDecoder(resp.Body(azblob.RetryReaderOptions{})).Decode(&checkpoint); err != nil {
+		return persist.Checkpoint{}, err
+	}
+	return checkpoint, nil
+}
+
+// ListOwnerships inspects the lease state of every partition blob in the consumer group and
+// reports the owner of each blob whose lease is currently held; blobs with no held lease are
+// left out of the result.
+func (s *BlobStore) ListOwnerships(ctx context.Context, namespace, hubName, consumerGroup string) ([]eventprocessor.Ownership, error) {
+	prefix := fmt.Sprintf("%s/%s/%s/", namespace, hubName, consumerGroup)
+	var ownerships []eventprocessor.Ownership
+
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := s.containerURL.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{
+			Prefix:  prefix,
+			Details: azblob.BlobListingDetails{Metadata: true},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "azureblob: failed to list ownership blobs")
+		}
+		marker = resp.NextMarker
+
+		for _, item := range resp.Segment.BlobItems {
+			if item.Properties.LeaseState != azblob.LeaseStateLeased {
+				continue
+			}
+			ownerships = append(ownerships, eventprocessor.Ownership{
+				Namespace:      namespace,
+				HubName:        hubName,
+				ConsumerGroup:  consumerGroup,
+				PartitionID:    item.Name[len(prefix):],
+				OwnerID:        ownerIDOf(item),
+				ExpirationTime: expirationOf(item),
+				ETag:           string(item.Properties.Etag),
+			})
+		}
+	}
+
+	return ownerships, nil
+}
+
+// ClaimOwnership attempts to acquire (or renew) the blob lease backing each requested ownership.
+// A partition whose lease is currently held by another host is dropped from the result.
+func (s *BlobStore) ClaimOwnership(ctx context.Context, ownerships ...eventprocessor.Ownership) ([]eventprocessor.Ownership, error) {
+	var claimed []eventprocessor.Ownership
+
+	for _, o := range ownerships {
+		blobURL := s.containerURL.NewBlobURL(blobName(o.Namespace, o.HubName, o.ConsumerGroup, o.PartitionID))
+
+		if _, err := blobURL.ToBlockBlobURL().Upload(ctx, bytesReader(nil), azblob.BlobHTTPHeaders{}, azblob.Metadata{ownerMetadataKey: o.OwnerID}, azblob.BlobAccessConditions{
+			ModifiedAccessConditions: azblob.ModifiedAccessConditions{IfNoneMatch: azblob.ETagAny},
+		}); err != nil && !isAlreadyExists(err) {
+			continue
+		}
+
+		leaseID := leaseIDFor(o.OwnerID)
+
+		// A host renewing a lease it already holds must use RenewLease: Acquire on an
+		// already-active lease fails with 409 LeaseAlreadyPresent. Only fall back to Acquire
+		// when there is no active lease under this host's deterministic lease ID yet, i.e. this
+		// is a fresh claim or a steal of an expired lease.
+		if _, err := blobURL.RenewLease(ctx, leaseID, azblob.ModifiedAccessConditions{}); err != nil {
+			if _, err := blobURL.AcquireLease(ctx, leaseID, int32(blobLeaseDuration.Seconds()), azblob.ModifiedAccessConditions{}); err != nil {
+				// Someone else already owns this lease; leave it out of the claimed set so the
+				// Processor's balancing loop tries again next tick.
+				continue
+			}
+		}
+
+		// Stamp ownership identity and lease expiry via SetMetadata, under the lease just
+		// (re)acquired, so ListOwnerships can report real per-owner expirations instead of the
+		// zero value planBalance treats as "unowned". SetMetadata - unlike the content Upload in
+		// Write - touches only metadata, so a concurrent checkpoint write can't race it off.
+		metadata := azblob.Metadata{
+			ownerMetadataKey:      o.OwnerID,
+			expirationMetadataKey: o.ExpirationTime.Format(time.RFC3339Nano),
+		}
+		if _, err := blobURL.SetMetadata(ctx, metadata, azblob.BlobAccessConditions{
+			LeaseAccessConditions: azblob.LeaseAccessConditions{LeaseID: leaseID},
+		}); err != nil {
+			continue
+		}
+
+		claimed = append(claimed, o)
+	}
+
+	return claimed, nil
+}
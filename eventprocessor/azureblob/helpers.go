@@ -0,0 +1,56 @@
+package azureblob
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/google/uuid"
+)
+
+const (
+	ownerMetadataKey      = "owner"
+	expirationMetadataKey = "expiration"
+)
+
+func bytesReader(b []byte) io.ReadSeeker {
+	return bytes.NewReader(b)
+}
+
+func isNotFound(err error) bool {
+	storageErr, ok := err.(azblob.StorageError)
+	return ok && storageErr.Response() != nil && storageErr.Response().StatusCode == http.StatusNotFound
+}
+
+func isAlreadyExists(err error) bool {
+	storageErr, ok := err.(azblob.StorageError)
+	return ok && storageErr.Response() != nil && storageErr.Response().StatusCode == http.StatusConflict
+}
+
+// ownerIDOf recovers the owner identifier stashed in the blob's metadata at claim time.
+func ownerIDOf(item azblob.BlobItemInternal) string {
+	return item.Metadata[ownerMetadataKey]
+}
+
+// expirationOf recovers the lease expiration time stashed in the blob's metadata at claim time.
+// A missing or unparseable value is treated as already expired, so planBalance offers the
+// partition back up rather than trusting a record it can't make sense of.
+func expirationOf(item azblob.BlobItemInternal) time.Time {
+	raw, ok := item.Metadata[expirationMetadataKey]
+	if !ok {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// leaseIDFor derives a stable lease ID for an owner so that the same host renews the same lease
+// instead of acquiring a fresh one on every tick.
+func leaseIDFor(ownerID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(ownerID)).String()
+}
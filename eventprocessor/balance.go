@@ -0,0 +1,90 @@
+package eventprocessor
+
+import (
+	"time"
+)
+
+// balancePlan describes, for a single rebalance tick, which partitions this host should
+// start owning and which it should give up.
+type balancePlan struct {
+	claim   []string
+	release []string
+}
+
+// planBalance computes a fair distribution of partitions across the hosts that currently hold
+// (or are attempting to hold) an unexpired lease. It targets ceil(len(partitionIDs)/activeOwners)
+// partitions per host. A host that owns more than its target releases the excess. A host that
+// owns less than its target first grabs any expired or unowned partition, and if none remain,
+// steals a single partition from whichever other host owns the most - this converges the fleet
+// towards balance over a handful of ticks without a central coordinator.
+func planBalance(ownerID string, partitionIDs []string, ownerships []Ownership, now time.Time) balancePlan {
+	owned := map[string]string{} // partitionID -> ownerID, only for unexpired leases
+	for _, o := range ownerships {
+		if !o.expired(now) {
+			owned[o.PartitionID] = o.OwnerID
+		}
+	}
+
+	countByOwner := map[string]int{}
+	for _, owner := range owned {
+		countByOwner[owner]++
+	}
+	if _, ok := countByOwner[ownerID]; !ok {
+		countByOwner[ownerID] = 0
+	}
+
+	activeOwners := len(countByOwner)
+	target := (len(partitionIDs) + activeOwners - 1) / activeOwners // ceil
+
+	var plan balancePlan
+	mine := countByOwner[ownerID]
+
+	if mine > target {
+		var toRelease []string
+		for _, id := range partitionIDs {
+			if owned[id] == ownerID {
+				toRelease = append(toRelease, id)
+			}
+		}
+		plan.release = toRelease[target:]
+		return plan
+	}
+
+	need := target - mine
+
+	// First pass: claim anything unowned or expired.
+	for _, id := range partitionIDs {
+		if need == 0 {
+			break
+		}
+		if _, ok := owned[id]; !ok {
+			plan.claim = append(plan.claim, id)
+			need--
+		}
+	}
+
+	// Second pass: steal a single partition from whichever other host currently owns the most,
+	// and only if that host is above the target, so that the steal does not knock it below the
+	// target.
+	if need > 0 {
+		maxOwner, maxCount := "", 0
+		for owner, count := range countByOwner {
+			if owner == ownerID {
+				continue
+			}
+			if count > maxCount {
+				maxOwner, maxCount = owner, count
+			}
+		}
+		if maxOwner != "" && maxCount > target {
+			for _, id := range partitionIDs {
+				if owned[id] == maxOwner {
+					plan.claim = append(plan.claim, id)
+					break
+				}
+			}
+		}
+	}
+
+	return plan
+}
@@ -0,0 +1,49 @@
+package eventprocessor
+
+import (
+	"context"
+	"time"
+)
+
+// Ownership represents a claim on a single partition by a single host. The Processor persists
+// ownership records through an OwnershipStore so that every host in a consumer group can see who
+// currently owns what, and for how much longer.
+type Ownership struct {
+	Namespace     string
+	HubName       string
+	ConsumerGroup string
+	PartitionID   string
+
+	// OwnerID identifies the host that holds (or is claiming) the partition.
+	OwnerID string
+
+	// ExpirationTime is when this ownership lease lapses. A zero value means the partition is
+	// unowned.
+	ExpirationTime time.Time
+
+	// ETag is an opaque version token used by the store to detect concurrent claims. Callers
+	// should round-trip whatever value they read back in on the next ClaimOwnership call.
+	ETag string
+}
+
+// expired returns true if the ownership lease is not currently held by anyone.
+func (o Ownership) expired(now time.Time) bool {
+	return o.ExpirationTime.IsZero() || now.After(o.ExpirationTime)
+}
+
+// OwnershipStore is implemented by types that can durably record which host owns which partition
+// of a consumer group. Implementations must make ClaimOwnership safe for concurrent callers
+// across processes: a claim should only succeed if the ownership record has not changed since it
+// was last listed (e.g. via an ETag / conditional write), so that two hosts racing to steal the
+// same partition can't both believe they succeeded.
+type OwnershipStore interface {
+	// ListOwnerships returns the current ownership record for every partition that has ever been
+	// claimed in the given consumer group. Partitions with no record yet are simply absent from
+	// the result.
+	ListOwnerships(ctx context.Context, namespace, hubName, consumerGroup string) ([]Ownership, error)
+
+	// ClaimOwnership attempts to write the given ownership records. It returns the subset that
+	// were successfully claimed; an ownership absent from the result lost a race to another host
+	// and was not applied.
+	ClaimOwnership(ctx context.Context, ownerships ...Ownership) ([]Ownership, error)
+}
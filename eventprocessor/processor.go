@@ -0,0 +1,286 @@
+// Package eventprocessor provides load-balanced, checkpointed consumption of an Event Hub across
+// a fleet of cooperating processes within the same consumer group.
+//
+// Where Hub.Receive leaves partition assignment and checkpoint coordination entirely up to the
+// caller, Processor discovers the partitions for a Hub, fairly distributes them across every
+// active host writing to the same OwnershipStore, and keeps rebalancing as hosts join, leave, or
+// fall behind on their lease renewals.
+package eventprocessor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	eventhub "github.com/Azure/azure-event-hubs-go"
+	"github.com/Azure/azure-event-hubs-go/persist"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLeaseDuration = 30 * time.Second
+	defaultTickInterval  = 10 * time.Second
+)
+
+type (
+	// Processor distributes ownership of the partitions of a Hub's consumer group across every
+	// Processor instance sharing the same OwnershipStore, and spawns a Hub.Receive loop for each
+	// partition it currently owns.
+	Processor struct {
+		hub           *eventhub.Hub
+		namespace     string
+		hubName       string
+		consumerGroup string
+		checkpointer  persist.CheckpointPersister
+		store         OwnershipStore
+		ownerID       string
+		leaseDuration time.Duration
+		tickInterval  time.Duration
+
+		mu    sync.Mutex
+		owned map[string]context.CancelFunc
+	}
+
+	// Option configures a Processor at construction time.
+	Option func(p *Processor) error
+)
+
+// New creates a Processor that will balance the partitions of hub's consumer group across every
+// other Processor sharing store. checkpointer is used to resume each partition from its last
+// committed checkpoint and to persist new checkpoints as events are processed.
+func New(hub *eventhub.Hub, namespace, hubName, consumerGroup string, checkpointer persist.CheckpointPersister, store OwnershipStore, opts ...Option) (*Processor, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Processor{
+		hub:           hub,
+		namespace:     namespace,
+		hubName:       hubName,
+		consumerGroup: consumerGroup,
+		checkpointer:  checkpointer,
+		store:         store,
+		ownerID:       id.String(),
+		leaseDuration: defaultLeaseDuration,
+		tickInterval:  defaultTickInterval,
+		owned:         make(map[string]context.CancelFunc),
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// WithOwnerID overrides the randomly generated host identifier used when claiming ownership
+// records. Set this when you need ownership to survive a process restart under a stable name.
+func WithOwnerID(ownerID string) Option {
+	return func(p *Processor) error {
+		p.ownerID = ownerID
+		return nil
+	}
+}
+
+// WithLeaseDuration configures how long a claimed partition is held before it must be renewed.
+// Any other Processor observing a lease older than this duration will treat the partition as
+// eligible to claim or steal.
+func WithLeaseDuration(d time.Duration) Option {
+	return func(p *Processor) error {
+		p.leaseDuration = d
+		return nil
+	}
+}
+
+// WithTickInterval configures how often the Processor re-evaluates partition ownership. It
+// should be meaningfully shorter than the lease duration so that a host renews before its leases
+// expire.
+func WithTickInterval(d time.Duration) Option {
+	return func(p *Processor) error {
+		p.tickInterval = d
+		return nil
+	}
+}
+
+// Run discovers the partitions of the Hub, begins balancing ownership of them against every other
+// Processor sharing the same OwnershipStore, and invokes handler for every event received from
+// each partition this host owns. Run blocks until ctx is cancelled, at which point it stops
+// receiving from every partition it owns. The ownership records in the OwnershipStore are left
+// as they are, so other hosts pick those partitions up only once their leases expire. A single partition failing to start or losing its receive loop does not stop Run;
+// that partition is simply dropped and picked back up on a later rebalance tick.
+func (p *Processor) Run(ctx context.Context, handler eventhub.Handler) error {
+	info, err := p.hub.GetRuntimeInformation(ctx)
+	if err != nil {
+		return errors.Wrap(err, "eventprocessor: failed to fetch partition IDs")
+	}
+
+	ticker := time.NewTicker(p.tickInterval)
+	defer ticker.Stop()
+
+	if err := p.rebalance(ctx, info.PartitionIDs, handler); err != nil {
+		log.Error(err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.releaseAll()
+			return ctx.Err()
+		case <-ticker.C:
+			if err := p.rebalance(ctx, info.PartitionIDs, handler); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
+
+// Close stops receiving from every partition this Processor currently owns. It does not update
+// the ownership records in the OwnershipStore, so other hosts in the consumer group pick those
+// partitions up once the leases lapse.
+func (p *Processor) Close() error {
+	p.releaseAll()
+	return nil
+}
+
+func (p *Processor) rebalance(ctx context.Context, partitionIDs []string, handler eventhub.Handler) error {
+	ownerships, err := p.store.ListOwnerships(ctx, p.namespace, p.hubName, p.consumerGroup)
+	if err != nil {
+		return errors.Wrap(err, "eventprocessor: failed to list ownerships")
+	}
+
+	plan := planBalance(p.ownerID, partitionIDs, ownerships, time.Now())
+
+	for _, partitionID := range plan.release {
+		p.release(partitionID)
+	}
+
+	// Renew every partition this host already owns on every tick, regardless of whether it is
+	// also claiming new ones this tick - otherwise a host busy stealing partitions could lose
+	// ones it already holds simply because their lease lapsed while it was occupied elsewhere.
+	if err := p.renew(ctx, partitionIDs); err != nil {
+		log.Error(errors.Wrap(err, "eventprocessor: failed to renew owned partitions"))
+	}
+
+	if len(plan.claim) == 0 {
+		return nil
+	}
+
+	var claims []Ownership
+	for _, partitionID := range plan.claim {
+		claims = append(claims, Ownership{
+			Namespace:      p.namespace,
+			HubName:        p.hubName,
+			ConsumerGroup:  p.consumerGroup,
+			PartitionID:    partitionID,
+			OwnerID:        p.ownerID,
+			ExpirationTime: time.Now().Add(p.leaseDuration),
+		})
+	}
+
+	claimed, err := p.store.ClaimOwnership(ctx, claims...)
+	if err != nil {
+		return errors.Wrap(err, "eventprocessor: failed to claim ownership")
+	}
+
+	for _, o := range claimed {
+		p.startPartition(ctx, o.PartitionID, handler)
+	}
+
+	return nil
+}
+
+// renew re-claims every partition this host currently owns so that its leases do not lapse while
+// no rebalancing is needed.
+func (p *Processor) renew(ctx context.Context, partitionIDs []string) error {
+	p.mu.Lock()
+	var mine []Ownership
+	for partitionID := range p.owned {
+		mine = append(mine, Ownership{
+			Namespace:      p.namespace,
+			HubName:        p.hubName,
+			ConsumerGroup:  p.consumerGroup,
+			PartitionID:    partitionID,
+			OwnerID:        p.ownerID,
+			ExpirationTime: time.Now().Add(p.leaseDuration),
+		})
+	}
+	p.mu.Unlock()
+
+	if len(mine) == 0 {
+		return nil
+	}
+
+	_, err := p.store.ClaimOwnership(ctx, mine...)
+	return err
+}
+
+func (p *Processor) startPartition(ctx context.Context, partitionID string, handler eventhub.Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.owned[partitionID]; ok {
+		return
+	}
+
+	partitionCtx, cancel := context.WithCancel(ctx)
+	p.owned[partitionID] = cancel
+
+	startOffset := persist.NewCheckpointFromStartOfStream()
+	if cp, err := p.checkpointer.Read(p.namespace, p.hubName, p.consumerGroup, partitionID); err == nil {
+		startOffset = cp
+	}
+
+	listener, err := p.hub.Receive(partitionCtx, partitionID, p.checkpointingHandler(partitionID, handler), eventhub.ReceiveWithStartingOffset(startOffset.Offset))
+	if err != nil {
+		// Drop just this partition; its lease is left to lapse and it will be claimed again (by
+		// this host or another) on a later rebalance tick instead of tearing down the Processor.
+		cancel()
+		delete(p.owned, partitionID)
+		log.Errorf("eventprocessor: failed to receive from partition %s, will retry on a later tick: %v", partitionID, err)
+		return
+	}
+
+	go func() {
+		select {
+		case <-listener.Done():
+			p.mu.Lock()
+			delete(p.owned, partitionID)
+			p.mu.Unlock()
+		case <-partitionCtx.Done():
+		}
+	}()
+}
+
+// checkpointingHandler wraps the user's Handler so that every processed event is checkpointed
+// through the configured persist.CheckpointPersister.
+func (p *Processor) checkpointingHandler(partitionID string, handler eventhub.Handler) eventhub.Handler {
+	return func(ctx context.Context, event *eventhub.Event) error {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+		return p.checkpointer.Write(p.namespace, p.hubName, p.consumerGroup, partitionID, event.GetCheckpoint())
+	}
+}
+
+func (p *Processor) release(partitionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cancel, ok := p.owned[partitionID]; ok {
+		cancel()
+		delete(p.owned, partitionID)
+	}
+}
+
+func (p *Processor) releaseAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for partitionID, cancel := range p.owned {
+		cancel()
+		delete(p.owned, partitionID)
+	}
+}
@@ -34,6 +34,9 @@ type (
 		senderMu          sync.Mutex
 		offsetPersister   persist.CheckpointPersister
 		userAgent         string
+
+		recoveryMaxRetries int
+		recoveryBackoff    BackoffFunc
 	}
 
 	// Handler is the function signature for any receiver of events
@@ -64,11 +67,13 @@ type (
 func NewHub(namespace, name string, tokenProvider auth.TokenProvider, opts ...HubOption) (*Hub, error) {
 	ns := newNamespace(namespace, tokenProvider, azure.PublicCloud)
 	h := &Hub{
-		name:            name,
-		namespace:       ns,
-		offsetPersister: persist.NewMemoryPersister(),
-		userAgent:       rootUserAgent,
-		receivers:       make(map[string]*receiver),
+		name:               name,
+		namespace:          ns,
+		offsetPersister:    persist.NewMemoryPersister(),
+		userAgent:          rootUserAgent,
+		receivers:          make(map[string]*receiver),
+		recoveryMaxRetries: defaultRecoveryMaxRetries,
+		recoveryBackoff:    DefaultBackoff,
 	}
 
 	for _, opt := range opts {
@@ -126,6 +131,31 @@ func NewHubFromEnvironment(opts ...HubOption) (*Hub, error) {
 	return NewHubWithNamespaceNameAndEnvironment(namespace, name, opts...)
 }
 
+// NewHubFromEnvironmentWithDefaultCredential creates a new Event Hub client for sending and
+// receiving messages, reading its namespace and name from the environment and authenticating
+// with aad.NewDefaultCredentialProvider's chain of environment, workload identity, managed
+// identity, and Azure CLI credentials. This gives pods on AKS, Azure VMs, and developer laptops
+// working authentication without any code changes between environments.
+func NewHubFromEnvironmentWithDefaultCredential(opts ...HubOption) (*Hub, error) {
+	const envErrMsg = "environment var %s must not be empty"
+	var namespace, name string
+
+	if namespace = os.Getenv("EVENTHUB_NAMESPACE"); namespace == "" {
+		return nil, errors.Errorf(envErrMsg, "EVENTHUB_NAMESPACE")
+	}
+
+	if name = os.Getenv("EVENTHUB_NAME"); name == "" {
+		return nil, errors.Errorf(envErrMsg, "EVENTHUB_NAME")
+	}
+
+	provider, err := aad.NewDefaultCredentialProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHub(namespace, name, provider, opts...)
+}
+
 // GetRuntimeInformation fetches runtime information from the Event Hub management node
 func (h *Hub) GetRuntimeInformation(ctx context.Context) (*mgmt.HubRuntimeInformation, error) {
 	client := mgmt.NewClient(h.namespace.name, h.name, h.namespace.tokenProvider, h.namespace.environment)
@@ -165,8 +195,21 @@ func (h *Hub) Close() error {
 	return lastErr
 }
 
-// Receive subscribes for messages sent to the provided entityPath.
+// Receive subscribes for messages sent to the provided entityPath. The returned ListenerHandle
+// transparently survives a recoverable link failure: the receiver is rebuilt (resuming from its
+// last checkpointed offset) under the Hub's recovery policy, and Done/Err/Close only reflect a
+// terminal failure or an explicit Close.
 func (h *Hub) Receive(ctx context.Context, partitionID string, handler Handler, opts ...ReceiveOption) (ListenerHandle, error) {
+	listener, err := h.receiveOnce(ctx, partitionID, handler, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return h.newRecoveringListener(ctx, partitionID, handler, opts, listener), nil
+}
+
+// receiveOnce builds a single receiver and starts listening on it, without any recovery wrapping.
+// It is also used directly by the recovering listener to rebuild a dropped receiver.
+func (h *Hub) receiveOnce(ctx context.Context, partitionID string, handler Handler, opts ...ReceiveOption) (ListenerHandle, error) {
 	h.receiverMu.Lock()
 	defer h.receiverMu.Unlock()
 
@@ -181,6 +224,11 @@ func (h *Hub) Receive(ctx context.Context, partitionID string, handler Handler,
 		}
 	}
 
+	if _, ok := cloudEventReceivers.Load(receiver); ok {
+		cloudEventReceivers.Delete(receiver)
+		handler = decodeCloudEventsHandler(handler)
+	}
+
 	h.receivers[receiver.getIdentifier()] = receiver
 	listenerContext := receiver.Listen(handler)
 
@@ -189,25 +237,28 @@ func (h *Hub) Receive(ctx context.Context, partitionID string, handler Handler,
 
 // Send sends an event to the Event Hub
 func (h *Hub) Send(ctx context.Context, event *Event, opts ...SendOption) error {
-	sender, err := h.getSender(ctx)
-	if err != nil {
-		return err
-	}
-
-	return sender.Send(ctx, event.toMsg(), opts...)
+	return h.sendWithRecovery(ctx, func() error {
+		sender, err := h.getSender(ctx)
+		if err != nil {
+			return err
+		}
+		return sender.Send(ctx, event.toMsg(), opts...)
+	})
 }
 
 // SendBatch sends an EventBatch to the Event Hub
 func (h *Hub) SendBatch(ctx context.Context, batch *EventBatch, opts ...SendOption) error {
-	sender, err := h.getSender(ctx)
-	if err != nil {
-		return err
-	}
 	msg, err := batch.toMsg()
 	if err != nil {
 		return err
 	}
-	return sender.Send(ctx, msg, opts...)
+	return h.sendWithRecovery(ctx, func() error {
+		sender, err := h.getSender(ctx)
+		if err != nil {
+			return err
+		}
+		return sender.Send(ctx, msg, opts...)
+	})
 }
 
 // HubWithPartitionedSender configures the Hub instance to send to a specific event Hub partition
@@ -268,6 +319,5 @@ func (h *Hub) getSender(ctx context.Context) (*sender, error) {
 		}
 		h.sender = s
 	}
-	// add recover logic here
 	return h.sender, nil
 }
@@ -0,0 +1,144 @@
+// Package azureblob provides a persist.CheckpointPersister backed by Azure Blob Storage, so that
+// consumption of an Event Hub can resume after a process restart instead of starting over from
+// the beginning (or end) of the stream.
+package azureblob
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/azure-event-hubs-go/persist"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/pkg/errors"
+)
+
+// ErrCheckpointConflict is returned by Write when another writer has updated the checkpoint for
+// the same partition since this persister last read it. Callers should re-read the checkpoint
+// and decide whether to retry.
+var ErrCheckpointConflict = errors.New("azureblob: checkpoint was modified by another writer")
+
+type (
+	// Persister implements persist.CheckpointPersister on top of a single Azure Blob Storage
+	// container, storing one blob per (namespace, hub, consumerGroup, partitionID) whose
+	// contents are the JSON encoded checkpoint.
+	Persister struct {
+		containerURL azblob.ContainerURL
+		etags        *etagCache
+	}
+
+	// Option configures a Persister at construction time.
+	Option func(p *Persister) error
+)
+
+// NewPersister creates a Persister backed by containerURL.
+func NewPersister(containerURL azblob.ContainerURL, opts ...Option) (*Persister, error) {
+	p := &Persister{
+		containerURL: containerURL,
+		etags:        newETagCache(),
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// WithAutoCreateContainer creates the backing container when NewPersister applies this option,
+// if it does not already exist, rather than requiring the caller to provision it out of band.
+func WithAutoCreateContainer() Option {
+	return func(p *Persister) error {
+		ctx := context.Background()
+		_, err := p.containerURL.Create(ctx, azblob.Metadata{}, azblob.PublicAccessNone)
+		if err != nil && !isAlreadyExists(err) {
+			return errors.Wrap(err, "azureblob: failed to create container")
+		}
+		return nil
+	}
+}
+
+func blobName(namespace, name, consumerGroup, partitionID string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", namespace, name, consumerGroup, partitionID)
+}
+
+// Write persists the checkpoint for the given partition with a conditional PUT against the
+// ETag this Persister last observed for it, returning ErrCheckpointConflict if another writer
+// updated the blob in the meantime.
+func (p *Persister) Write(namespace, name, consumerGroup, partitionID string, checkpoint persist.Checkpoint) error {
+	ctx := context.Background()
+	key := blobName(namespace, name, consumerGroup, partitionID)
+	blobURL := p.containerURL.NewBlockBlobURL(key)
+
+	body, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "azureblob: failed to marshal checkpoint")
+	}
+
+	conditions := azblob.BlobAccessConditions{}
+	if etag, ok := p.etags.get(key); ok {
+		conditions.ModifiedAccessConditions.IfMatch = etag
+	} else {
+		conditions.ModifiedAccessConditions.IfNoneMatch = azblob.ETagAny
+	}
+
+	resp, err := blobURL.Upload(ctx, bytes.NewReader(body), azblob.BlobHTTPHeaders{ContentType: "application/json"}, azblob.Metadata{}, conditions)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return ErrCheckpointConflict
+		}
+		return errors.Wrap(err, "azureblob: failed to upload checkpoint")
+	}
+
+	p.etags.set(key, resp.ETag())
+	return nil
+}
+
+// Read returns the last checkpoint written for the given partition, or a start-of-stream
+// checkpoint if the partition has never been checkpointed.
+func (p *Persister) Read(namespace, name, consumerGroup, partitionID string) (persist.Checkpoint, error) {
+	ctx := context.Background()
+	key := blobName(namespace, name, consumerGroup, partitionID)
+	blobURL := p.containerURL.NewBlockBlobURL(key)
+
+	resp, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		if isNotFound(err) {
+			return persist.NewCheckpointFromStartOfStream(), nil
+		}
+		return persist.Checkpoint{}, errors.Wrap(err, "azureblob: failed to download checkpoint")
+	}
+	defer resp.Response().Body.Close()
+
+	var checkpoint persist.Checkpoint
+	if err := json.NewDecoder(resp.Body(azblob.RetryReaderOptions{})).Decode(&checkpoint); err != nil {
+		return persist.Checkpoint{}, errors.Wrap(err, "azureblob: failed to unmarshal checkpoint")
+	}
+
+	p.etags.set(key, resp.ETag())
+	return checkpoint, nil
+}
+
+func isNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+func isPreconditionFailed(err error) bool {
+	code := statusCode(err)
+	return code == http.StatusPreconditionFailed || code == http.StatusConflict
+}
+
+func isAlreadyExists(err error) bool {
+	return statusCode(err) == http.StatusConflict
+}
+
+func statusCode(err error) int {
+	if storageErr, ok := err.(azblob.StorageError); ok && storageErr.Response() != nil {
+		return storageErr.Response().StatusCode
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package azureblob
+
+import (
+	"sync"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// etagCache remembers the last ETag this Persister observed for each blob, so that Write can
+// issue a conditional PUT without a read-before-write round trip on the common path.
+type etagCache struct {
+	mu     sync.Mutex
+	values map[string]azblob.ETag
+}
+
+func newETagCache() *etagCache {
+	return &etagCache{values: make(map[string]azblob.ETag)}
+}
+
+func (c *etagCache) get(key string) (azblob.ETag, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	etag, ok := c.values[key]
+	return etag, ok
+}
+
+func (c *etagCache) set(key string, etag azblob.ETag) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = etag
+}
@@ -0,0 +1,128 @@
+package eventhub
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// MultiPartitionListener is the composite ListenerHandle returned by Hub.ReceiveAll. Its
+	// Done channel closes as soon as any one of its per-partition listeners stops, its Errors
+	// channel reports every per-partition error as it happens, Err reports only the most recent
+	// one (satisfying ListenerHandle), and Close shuts down every partition listener it owns.
+	MultiPartitionListener struct {
+		children  []ListenerHandle
+		done      chan struct{}
+		closeOnce sync.Once
+		errs      chan error
+
+		mu      sync.Mutex
+		lastErr error
+	}
+
+	// ReceiveAllOption configures a Hub.ReceiveAll call.
+	ReceiveAllOption func(cfg *receiveAllConfig) error
+
+	receiveAllConfig struct {
+		filter func(partitionID string) bool
+	}
+)
+
+// ReceiveAllWithPartitionFilter restricts ReceiveAll to the partitions for which filter returns
+// true, instead of every partition reported by GetRuntimeInformation.
+func ReceiveAllWithPartitionFilter(filter func(partitionID string) bool) ReceiveAllOption {
+	return func(cfg *receiveAllConfig) error {
+		cfg.filter = filter
+		return nil
+	}
+}
+
+// ReceiveAll enumerates every partition of the Hub via GetRuntimeInformation and calls Receive
+// for each one, fanning handler out across the whole Hub from a single process. It is a
+// convenience for the common case that would otherwise require callers to hand-write a loop
+// around GetRuntimeInformation and Receive.
+func (h *Hub) ReceiveAll(ctx context.Context, handler Handler, opts ...ReceiveAllOption) (ListenerHandle, error) {
+	cfg := &receiveAllConfig{}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := h.GetRuntimeInformation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "eventhub: failed to enumerate partitions for ReceiveAll")
+	}
+
+	m := &MultiPartitionListener{
+		done: make(chan struct{}),
+		errs: make(chan error, len(info.PartitionIDs)),
+	}
+
+	for _, partitionID := range info.PartitionIDs {
+		if cfg.filter != nil && !cfg.filter(partitionID) {
+			continue
+		}
+
+		listener, err := h.Receive(ctx, partitionID, handler)
+		if err != nil {
+			_ = m.Close(ctx)
+			return nil, errors.Wrapf(err, "eventhub: ReceiveAll failed to receive from partition %s", partitionID)
+		}
+
+		m.children = append(m.children, listener)
+		go m.watch(listener)
+	}
+
+	return m, nil
+}
+
+// watch waits for a single child listener to stop, forwards its error (if any) on the Errors
+// channel and as the most recent error reported by Err, and signals Done once the first child
+// stops.
+func (m *MultiPartitionListener) watch(listener ListenerHandle) {
+	<-listener.Done()
+	if err := listener.Err(); err != nil {
+		m.mu.Lock()
+		m.lastErr = err
+		m.mu.Unlock()
+		m.errs <- err
+	}
+	m.closeOnce.Do(func() { close(m.done) })
+}
+
+// Done returns a channel that closes as soon as any one partition listener stops, whether
+// because of an error or because Close was called.
+func (m *MultiPartitionListener) Done() <-chan struct{} {
+	return m.done
+}
+
+// Errors returns a channel on which every per-partition listener error is delivered as it
+// occurs, so callers can distinguish which partition failed instead of only learning that one
+// did.
+func (m *MultiPartitionListener) Errors() <-chan error {
+	return m.errs
+}
+
+// Err returns the most recently observed per-partition error, satisfying the ListenerHandle
+// contract. Callers that need to know which partition failed, or want to observe every failure
+// rather than just the latest, should use Errors instead.
+func (m *MultiPartitionListener) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastErr
+}
+
+// Close shuts down every partition listener this handle owns.
+func (m *MultiPartitionListener) Close(ctx context.Context) error {
+	var lastErr error
+	for _, child := range m.children {
+		if err := child.Close(ctx); err != nil {
+			lastErr = err
+		}
+	}
+	m.closeOnce.Do(func() { close(m.done) })
+	return lastErr
+}
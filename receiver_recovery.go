@@ -0,0 +1,131 @@
+package eventhub
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// recoveringListener wraps the ListenerHandle for a single partition so that a recoverable link
+// failure - a detached link, a forced connection close, an idle timeout - transparently rebuilds
+// the receiver instead of surfacing the failure to the caller. The rebuilt receiver resumes from
+// its last checkpointed offset, the same way any newly constructed receiver does when the Hub is
+// configured with HubWithOffsetPersistence. Done, Err, and Close only ever reflect a terminal
+// failure or an explicit Close; intermediate recoverable failures are invisible to the caller.
+type recoveringListener struct {
+	mu      sync.Mutex
+	current ListenerHandle
+	done    chan struct{}
+	err     error
+	closed  bool
+}
+
+// newRecoveringListener starts watching listener and returns a handle that keeps rebuilding the
+// partitionID receiver under the Hub's recovery policy for as long as failures remain
+// recoverable.
+func (h *Hub) newRecoveringListener(ctx context.Context, partitionID string, handler Handler, opts []ReceiveOption, listener ListenerHandle) *recoveringListener {
+	rl := &recoveringListener{current: listener, done: make(chan struct{})}
+	go h.watchReceiver(ctx, partitionID, handler, opts, rl)
+	return rl
+}
+
+func (h *Hub) watchReceiver(ctx context.Context, partitionID string, handler Handler, opts []ReceiveOption, rl *recoveringListener) {
+	for {
+		rl.mu.Lock()
+		current := rl.current
+		rl.mu.Unlock()
+
+		<-current.Done()
+
+		rl.mu.Lock()
+		if rl.closed {
+			rl.mu.Unlock()
+			return
+		}
+		rl.mu.Unlock()
+
+		err := current.Err()
+		if !isRecoverable(err) {
+			rl.finish(err)
+			return
+		}
+
+		log.Debugf("eventhub: receiver for partition %s lost its link, recovering: %v", partitionID, err)
+
+		rebuilt, err := h.rebuildReceiver(ctx, partitionID, handler, opts)
+		if err != nil {
+			rl.finish(err)
+			return
+		}
+
+		rl.mu.Lock()
+		if rl.closed {
+			rl.mu.Unlock()
+			if err := rebuilt.Close(ctx); err != nil {
+				log.Error(err)
+			}
+			return
+		}
+		rl.current = rebuilt
+		rl.mu.Unlock()
+	}
+}
+
+// rebuildReceiver repeatedly calls receiveOnce under the Hub's recovery policy until it succeeds,
+// a terminal error is hit, or the retry budget is exhausted.
+func (h *Hub) rebuildReceiver(ctx context.Context, partitionID string, handler Handler, opts []ReceiveOption) (ListenerHandle, error) {
+	var listener ListenerHandle
+	err := retryRecover(ctx, h.recoveryMaxRetries, h.recoveryBackoff, func() error {
+		l, err := h.receiveOnce(ctx, partitionID, handler, opts...)
+		if err != nil {
+			return err
+		}
+		listener = l
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return listener, nil
+}
+
+func (rl *recoveringListener) finish(err error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.closed {
+		return
+	}
+	rl.err = err
+	rl.closed = true
+	close(rl.done)
+}
+
+// Done returns a channel that closes once recovery has given up - either a terminal error was
+// hit or the recovery policy's retry budget was exhausted - or Close was called.
+func (rl *recoveringListener) Done() <-chan struct{} {
+	return rl.done
+}
+
+// Err returns the error that stopped recovery, if any.
+func (rl *recoveringListener) Err() error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.err
+}
+
+// Close shuts down the currently active receiver and stops any further recovery attempts. A
+// rebuild already under way when Close is called is closed as soon as it returns a receiver.
+func (rl *recoveringListener) Close(ctx context.Context) error {
+	rl.mu.Lock()
+	if rl.closed {
+		rl.mu.Unlock()
+		return nil
+	}
+	rl.closed = true
+	current := rl.current
+	close(rl.done)
+	rl.mu.Unlock()
+
+	return current.Close(ctx)
+}
@@ -0,0 +1,144 @@
+package eventhub
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRecoveryMaxRetries = 10
+	defaultBackoffBase        = 100 * time.Millisecond
+	defaultBackoffCap         = 30 * time.Second
+)
+
+// BackoffFunc computes how long to wait before the (attempt+1)th reconnect attempt, where attempt
+// is zero for the first retry.
+type BackoffFunc func(attempt int) time.Duration
+
+// DefaultBackoff is an exponential backoff starting at 100ms, capped at 30 seconds, and jittered
+// by up to 20% so that many clients recovering from the same outage don't reconnect in lockstep.
+func DefaultBackoff(attempt int) time.Duration {
+	backoff := defaultBackoffBase * time.Duration(int64(1)<<uint(attempt))
+	if backoff <= 0 || backoff > defaultBackoffCap {
+		backoff = defaultBackoffCap
+	}
+	jitter := time.Duration(rand.Int63n(int64(backoff)/5 + 1))
+	return backoff + jitter
+}
+
+// HubWithRecoveryPolicy configures how many times, and how long to wait between attempts, the
+// Hub will try to rebuild a sender or receiver link after a recoverable AMQP error - a detached
+// link, a forced connection close, or an idle timeout. Non-recoverable errors, such as an
+// authentication failure or a missing entity, are returned to the caller immediately regardless
+// of this policy.
+func HubWithRecoveryPolicy(maxRetries int, backoff BackoffFunc) HubOption {
+	return func(h *Hub) error {
+		h.recoveryMaxRetries = maxRetries
+		h.recoveryBackoff = backoff
+		return nil
+	}
+}
+
+// terminalConditions match AMQP and HTTP error text that will not be resolved by rebuilding the
+// link - the caller's credentials, request, or the entity itself are the problem, not the
+// connection.
+var terminalConditions = []string{
+	"amqp:unauthorized-access",
+	"status-code: 401",
+	"status-code: 404",
+	"resourcelimitexceeded",
+	"entity not found",
+	"quota exceeded",
+}
+
+// recoverableConditions match AMQP and network error text indicating the link or connection was
+// dropped out from under us, but the entity and credentials are otherwise fine.
+var recoverableConditions = []string{
+	"amqp:link:detach-forced",
+	"amqp:connection:forced",
+	"amqp:link:transfer-limit-exceeded",
+	"idle timeout",
+	"connection reset",
+	"use of closed network connection",
+	"eof",
+}
+
+// isTerminal reports whether err represents a condition that retrying will not fix.
+func isTerminal(err error) bool {
+	return matchesAny(err, terminalConditions)
+}
+
+// isRecoverable reports whether err looks like a dropped AMQP link or connection that can be
+// repaired by rebuilding it.
+func isRecoverable(err error) bool {
+	if err == nil || isTerminal(err) {
+		return false
+	}
+	return matchesAny(err, recoverableConditions)
+}
+
+func matchesAny(err error, conditions []string) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(errors.Cause(err).Error())
+	for _, condition := range conditions {
+		if strings.Contains(msg, condition) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryRecover calls attempt once and then retries it up to maxRetries more times, stopping early
+// when it succeeds or a terminal error is hit, and sleeping according to backoff between tries. Both Hub.sendWithRecovery and
+// the receiver's link-recovery loop share this so that senders and receivers follow the same
+// recovery policy.
+func retryRecover(ctx context.Context, maxRetries int, backoff BackoffFunc, attempt func() error) error {
+	var lastErr error
+	for try := 0; try <= maxRetries; try++ {
+		if try > 0 {
+			wait := backoff(try - 1)
+			log.Debugf("eventhub: attempting link recovery %d/%d after %s", try, maxRetries, wait)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if isTerminal(err) {
+			return err
+		}
+		log.Errorf("eventhub: link recovery attempt %d failed: %v", try, err)
+	}
+	return errors.Wrapf(lastErr, "eventhub: exhausted %d link recovery retries", maxRetries)
+}
+
+// sendWithRecovery runs send once, and if it fails with a recoverable error, drops the cached
+// sender so the next getSender call rebuilds the link, then retries send under the Hub's
+// recovery policy.
+func (h *Hub) sendWithRecovery(ctx context.Context, send func() error) error {
+	err := send()
+	if err == nil || !isRecoverable(err) {
+		return err
+	}
+
+	log.Debugf("eventhub: send failed with recoverable error, rebuilding sender link: %v", err)
+	h.senderMu.Lock()
+	h.sender = nil
+	h.senderMu.Unlock()
+
+	return retryRecover(ctx, h.recoveryMaxRetries, h.recoveryBackoff, send)
+}